@@ -0,0 +1,107 @@
+// Package deliverystore tracks webhook delivery IDs so a Server can reject
+// replayed deliveries instead of processing them twice.
+package deliverystore
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Store records delivery IDs that have already been processed so retried
+// or replayed webhooks can be rejected. Seen and Record are split so a
+// caller can check for a replay before doing the work of processing a
+// delivery, and only Record it once that work has actually succeeded.
+type Store interface {
+	// Seen reports whether id has already been recorded within the
+	// store's retention window. It does not itself record id.
+	Seen(id string) bool
+
+	// Record marks id as seen, within the store's retention window.
+	Record(id string)
+}
+
+type entry struct {
+	id     string
+	seenAt time.Time
+}
+
+// LRU is an in-memory Store bounded by both a maximum size and a TTL. It is
+// the default DeliveryStore used by Server.
+type LRU struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	maxItems int
+	order    *list.List
+	index    map[string]*list.Element
+}
+
+// NewLRU returns an LRU store that remembers at most maxItems delivery IDs,
+// each for ttl before it's eligible for reuse.
+func NewLRU(maxItems int, ttl time.Duration) *LRU {
+	return &LRU{
+		ttl:      ttl,
+		maxItems: maxItems,
+		order:    list.New(),
+		index:    make(map[string]*list.Element),
+	}
+}
+
+func (l *LRU) Seen(id string) bool {
+	if id == "" {
+		// Providers that don't send a delivery ID can't be deduplicated.
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.evictExpired()
+
+	_, ok := l.index[id]
+	return ok
+}
+
+func (l *LRU) Record(id string) {
+	if id == "" {
+		// Providers that don't send a delivery ID can't be deduplicated.
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.evictExpired()
+
+	if el, ok := l.index[id]; ok {
+		el.Value.(*entry).seenAt = time.Now()
+		l.order.MoveToFront(el)
+		return
+	}
+
+	el := l.order.PushFront(&entry{id: id, seenAt: time.Now()})
+	l.index[id] = el
+
+	if l.order.Len() > l.maxItems {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.index, oldest.Value.(*entry).id)
+	}
+}
+
+// evictExpired drops entries older than ttl. Entries are kept in order of
+// last-seen time, oldest at the back, so it's enough to trim from the back
+// until we hit one that's still live.
+func (l *LRU) evictExpired() {
+	for {
+		back := l.order.Back()
+		if back == nil {
+			return
+		}
+		if time.Since(back.Value.(*entry).seenAt) <= l.ttl {
+			return
+		}
+		l.order.Remove(back)
+		delete(l.index, back.Value.(*entry).id)
+	}
+}
@@ -0,0 +1,157 @@
+// Package deploy consumes hookserve events and runs declarative deploy
+// actions in response, similar to Caddy's git directive: pull a repo and
+// run a list of commands.
+package deploy
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phayes/hookserve/hookserve/providers"
+	"github.com/phayes/hookserve/hookserve/providers/github/events"
+)
+
+// maxDeployAttempts bounds how many times work attempts a single failing
+// job, the first run included, before giving up on it and moving on to the
+// next one.
+const maxDeployAttempts = 5
+
+// RepoSpec declares a repository to keep checked out and the commands to
+// run after each update.
+type RepoSpec struct {
+	Owner   string   // GitHub repository owner
+	Repo    string   // GitHub repository name
+	Branch  string   // Branch to track
+	Path    string   // Local working directory to clone/pull into
+	Then    []string // Shell commands to run serially after a successful pull
+	KeyFile string   // Optional SSH private key to use for the clone/pull
+}
+
+func repoKey(owner, repo, branch string) string {
+	return owner + "/" + repo + "@" + branch
+}
+
+// Deployer runs RepoSpec actions in response to push events read off a
+// hookserve Server.Events channel. Deliveries for the same repo are
+// serialized through a per-repo worker queue so concurrent webhooks never
+// race on the same working tree.
+type Deployer struct {
+	// Backoff returns how long to wait before retrying a repo's deploy
+	// after attempt consecutive failures. Defaults to exponential backoff
+	// capped at one minute.
+	Backoff func(attempt int) time.Duration
+
+	mu    sync.Mutex
+	repos map[string]*repoWorker
+}
+
+// NewDeployer returns a Deployer with no repos registered.
+func NewDeployer() *Deployer {
+	return &Deployer{
+		Backoff: defaultBackoff,
+	}
+}
+
+func defaultBackoff(attempt int) time.Duration {
+	d := time.Second << uint(attempt)
+	if d <= 0 || d > time.Minute {
+		d = time.Minute
+	}
+	return d
+}
+
+type job struct {
+	commit     string
+	deliveryID string
+}
+
+type repoWorker struct {
+	spec     RepoSpec
+	jobs     chan job
+	failures int
+}
+
+// AddRepo registers spec and starts the worker goroutine that will run its
+// deploys.
+func (d *Deployer) AddRepo(spec RepoSpec) {
+	w := &repoWorker{spec: spec, jobs: make(chan job, 8)}
+
+	d.mu.Lock()
+	if d.repos == nil {
+		d.repos = make(map[string]*repoWorker)
+	}
+	d.repos[repoKey(spec.Owner, spec.Repo, spec.Branch)] = w
+	d.mu.Unlock()
+
+	go d.work(w)
+}
+
+// Watch consumes events from ch, forwarding matching push events to their
+// registered repo's worker queue. It blocks until ch is closed, so callers
+// typically run it in its own goroutine alongside a Server's Events
+// channel.
+func (d *Deployer) Watch(ch <-chan providers.Event) {
+	for event := range ch {
+		push, ok := event.Payload.(*events.PushEvent)
+		if !ok {
+			continue
+		}
+		d.dispatch(push, event.DeliveryID)
+	}
+}
+
+func (d *Deployer) dispatch(push *events.PushEvent, deliveryID string) {
+	owner := push.Repository.Owner.Login
+	repo := push.Repository.Name
+	branch := strings.TrimPrefix(push.Ref, "refs/heads/")
+
+	d.mu.Lock()
+	w, ok := d.repos[repoKey(owner, repo, branch)]
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	w.jobs <- job{commit: push.After, deliveryID: deliveryID}
+}
+
+// work runs w's jobs serially, retrying each one with backoff up to
+// maxDeployAttempts before giving up on it and moving on to the next job.
+func (d *Deployer) work(w *repoWorker) {
+	for j := range w.jobs {
+		for {
+			err := runDeploy(w.spec, j)
+			if err == nil {
+				w.failures = 0
+				break
+			}
+
+			w.failures++
+			if w.failures >= maxDeployAttempts {
+				log.Printf("deploy: giving up on %s/%s@%s after %d attempts (delivery %s): %v",
+					w.spec.Owner, w.spec.Repo, w.spec.Branch, w.failures, j.deliveryID, err)
+				w.failures = 0
+				break
+			}
+			time.Sleep(d.backoff(w.failures))
+		}
+	}
+}
+
+// backoff returns d.Backoff, falling back to defaultBackoff for a
+// Deployer built without NewDeployer.
+func (d *Deployer) backoff(attempt int) time.Duration {
+	if d.Backoff == nil {
+		return defaultBackoff(attempt)
+	}
+	return d.Backoff(attempt)
+}
+
+func runDeploy(spec RepoSpec, j job) error {
+	if err := syncRepo(spec); err != nil {
+		return err
+	}
+	return runThen(spec, j)
+}
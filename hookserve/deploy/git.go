@@ -0,0 +1,59 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+func cloneURL(spec RepoSpec) string {
+	return fmt.Sprintf("git@github.com:%s/%s.git", spec.Owner, spec.Repo)
+}
+
+// syncRepo clones spec.Path if it doesn't yet exist, otherwise pulls the
+// tracked branch.
+func syncRepo(spec RepoSpec) error {
+	if _, err := os.Stat(filepath.Join(spec.Path, ".git")); err == nil {
+		return gitCommand(spec, spec.Path, "pull", "origin", spec.Branch)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(spec.Path), 0755); err != nil {
+		return err
+	}
+	return gitCommand(spec, "", "clone", "-b", spec.Branch, cloneURL(spec), spec.Path)
+}
+
+func gitCommand(spec RepoSpec, dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	if spec.KeyFile != "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -i "+spec.KeyFile+" -o StrictHostKeyChecking=no")
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runThen runs each of spec.Then serially in spec.Path, stopping at the
+// first failure.
+func runThen(spec RepoSpec, j job) error {
+	for _, line := range spec.Then {
+		cmd := exec.Command("sh", "-c", line)
+		cmd.Dir = spec.Path
+		cmd.Env = append(os.Environ(),
+			"HOOK_OWNER="+spec.Owner,
+			"HOOK_REPO="+spec.Repo,
+			"HOOK_BRANCH="+spec.Branch,
+			"HOOK_COMMIT="+j.commit,
+			"HOOK_DELIVERY="+j.deliveryID,
+		)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -8,12 +8,19 @@ HookServe is a small golang utility for receiving github webhooks. It's easy to
     for {
         select {
         case event := <-server.Events:
-            fmt.Println(event.Owner + " " + event.Repo + " " + event.Branch + " " + event.Commit)
+            switch payload := event.Payload.(type) {
+            case *events.PushEvent:
+                fmt.Println(payload.Repository.FullName + " " + payload.Ref + " " + payload.After)
+            case *events.PullRequestEvent:
+                fmt.Println(payload.Repository.FullName + " PR #" + strconv.Itoa(payload.Number) + " " + payload.Action)
+            }
         default:
             time.Sleep(100)
         }
     }
 
+By default only push and pull_request events are delivered. Set Server.EventTypes to accept more of the event types modeled in the hookserve/providers/github/events subpackage (issues, issue_comment, pull_request_review, release, create, delete, ping, check_run, workflow_run, ...).
+
 
 Command Line Utility
 
@@ -0,0 +1,150 @@
+// Package forward re-posts validated webhook deliveries to local URLs,
+// turning a hookserve Server into a relay for local development -- similar
+// in spirit to `gh webhook forward` or smee, but without depending on
+// either.
+package forward
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	queueSize   = 32
+	maxAttempts = 5
+	maxRecent   = 20
+)
+
+// ForwardTarget is a local URL that validated webhook deliveries are
+// re-posted to.
+type ForwardTarget struct {
+	URL             string   // Local URL to forward deliveries to, eg. "http://localhost:3000/hook"
+	HeaderAllowlist []string // Extra request headers to copy through, beyond the event type and delivery ID
+	ResignSecret    string   // If set, re-sign the forwarded body with this secret as X-Hub-Signature-256
+
+	once  sync.Once
+	queue chan delivery
+
+	mu     sync.Mutex
+	recent []Outcome
+}
+
+// Outcome records the result of one forwarding attempt, kept for the
+// Server's /status endpoint.
+type Outcome struct {
+	DeliveryID string    `json:"delivery_id"`
+	Status     int       `json:"status,omitempty"`
+	Err        string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+type delivery struct {
+	eventType  string
+	deliveryID string
+	headers    http.Header
+	body       []byte
+}
+
+// Forward queues body for delivery to t, copying eventType and deliveryID
+// through as X-GitHub-Event / X-GitHub-Delivery, plus any headers in
+// t.HeaderAllowlist. Forward never blocks: if t's queue is full, the
+// delivery is dropped and recorded as a failed Outcome.
+func (t *ForwardTarget) Forward(eventType, deliveryID string, headers http.Header, body []byte) {
+	t.once.Do(t.start)
+
+	select {
+	case t.queue <- delivery{eventType: eventType, deliveryID: deliveryID, headers: headers, body: body}:
+	default:
+		t.record(Outcome{DeliveryID: deliveryID, Err: "forward queue full, delivery dropped", At: time.Now()})
+	}
+}
+
+func (t *ForwardTarget) start() {
+	t.queue = make(chan delivery, queueSize)
+	go t.work()
+}
+
+func (t *ForwardTarget) work() {
+	for d := range t.queue {
+		t.deliver(d)
+	}
+}
+
+func (t *ForwardTarget) deliver(d delivery) {
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff(attempt))
+		}
+
+		status, err := t.post(d)
+		if err == nil && status < 500 {
+			t.record(Outcome{DeliveryID: d.deliveryID, Status: status, At: time.Now()})
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("upstream returned %d", status)
+		}
+		lastErr = err
+	}
+	t.record(Outcome{DeliveryID: d.deliveryID, Err: lastErr.Error(), At: time.Now()})
+}
+
+func backoff(attempt int) time.Duration {
+	d := time.Second << uint(attempt-2)
+	if d > 30*time.Second {
+		d = 30 * time.Second
+	}
+	return d
+}
+
+func (t *ForwardTarget) post(d delivery) (int, error) {
+	req, err := http.NewRequest("POST", t.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", d.eventType)
+	req.Header.Set("X-GitHub-Delivery", d.deliveryID)
+	for _, name := range t.HeaderAllowlist {
+		if v := d.headers.Get(name); v != "" {
+			req.Header.Set(name, v)
+		}
+	}
+	if t.ResignSecret != "" {
+		mac := hmac.New(sha256.New, []byte(t.ResignSecret))
+		mac.Write(d.body)
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (t *ForwardTarget) record(o Outcome) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recent = append(t.recent, o)
+	if len(t.recent) > maxRecent {
+		t.recent = t.recent[len(t.recent)-maxRecent:]
+	}
+}
+
+// Recent returns t's most recent delivery outcomes, oldest first.
+func (t *ForwardTarget) Recent() []Outcome {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Outcome, len(t.recent))
+	copy(out, t.recent)
+	return out
+}
@@ -0,0 +1,25 @@
+package forward
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// targetStatus is the JSON shape served by a Server's /status endpoint for
+// a single ForwardTarget.
+type targetStatus struct {
+	URL    string    `json:"url"`
+	Recent []Outcome `json:"recent"`
+}
+
+// WriteStatus writes a JSON report of each target's recent delivery
+// outcomes to w.
+func WriteStatus(w http.ResponseWriter, targets []*ForwardTarget) {
+	report := make([]targetStatus, len(targets))
+	for i, t := range targets {
+		report[i] = targetStatus{URL: t.URL, Recent: t.Recent()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
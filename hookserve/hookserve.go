@@ -1,112 +1,82 @@
 package hookserve
 
 import (
-	"crypto/hmac"
-	"crypto/sha1"
-	"encoding/hex"
+	"context"
 	"errors"
-	"github.com/bmatsuo/go-jsontree"
 	"io/ioutil"
 	"net/http"
 	"strconv"
-	"strings"
-)
-
-var ErrInvalidEventFormat = errors.New("Unable to parse event string. Invalid Format.")
-
-type Event struct {
-	Owner      string // The username of the owner of the repository
-	Repo       string // The name of the repository
-	Branch     string // The branch the event took place on
-	Commit     string // The head commit hash attached to the event
-	Type       string // Can be either "pull_request" or "push"
-	Action     string // For Pull Requests, contains "assigned", "unassigned", "labeled", "unlabeled", "opened", "closed", "reopened", or "synchronize".
-	BaseOwner  string // For Pull Requests, contains the base owner
-	BaseRepo   string // For Pull Requests, contains the base repo
-	BaseBranch string // For Pull Requests, contains the base branch
-}
+	"time"
 
-// Create a new event from a string, the string format being the same as the one produced by event.String()
-func NewEvent(e string) (*Event, error) {
-	// Trim whitespace
-	e = strings.Trim(e, "\n\t ")
+	"github.com/phayes/hookserve/hookserve/deliverystore"
+	"github.com/phayes/hookserve/hookserve/forward"
+	"github.com/phayes/hookserve/hookserve/providers"
+	"github.com/phayes/hookserve/hookserve/providers/github"
+	"github.com/phayes/hookserve/hookserve/providers/github/events"
+	"github.com/phayes/hookserve/hookserve/store"
+)
 
-	// Split into lines
-	parts := strings.Split(e, "\n")
+// DefaultEventTypes is the set of GitHub event types a Server accepts when
+// EventTypes is left unset, preserving the behavior of earlier versions of
+// hookserve that only understood push and pull_request.
+var DefaultEventTypes = []string{"push", "pull_request"}
 
-	// Sanity checking
-	if len(parts) != 5 || len(parts) != 8 {
-		return nil, ErrInvalidEventFormat
-	}
-	for _, item := range parts {
-		if len(item) < 8 {
-			return nil, ErrInvalidEventFormat
-		}
-	}
+// DefaultDeliveryStoreSize and DefaultDeliveryTTL size the in-memory
+// DeliveryStore a Server uses when none is configured.
+const (
+	DefaultDeliveryStoreSize = 1024
+	DefaultDeliveryTTL       = 24 * time.Hour
+)
 
-	// Fill in values for the event
-	event := Event{}
-	event.Type = parts[0][8:]
-	event.Owner = parts[1][8:]
-	event.Repo = parts[2][8:]
-	event.Branch = parts[3][8:]
-	event.Commit = parts[4][8:]
-
-	// Fill in extra values if it's a pull_request
-	if event.Type == "pull_request" {
-		if len(parts) == 9 { // New format
-			event.Action = parts[5][8:]
-			event.BaseOwner = parts[6][8:]
-			event.BaseRepo = parts[7][8:]
-			event.BaseBranch = parts[8][8:]
-		} else if len(parts) == 8 { // Old Format
-			event.BaseOwner = parts[5][8:]
-			event.BaseRepo = parts[6][8:]
-			event.BaseBranch = parts[7][8:]
-		} else {
-			return nil, ErrInvalidEventFormat
-		}
-	}
+// DefaultStatusPath is the path a Server reports forwarding outcomes on
+// when StatusPath is left unset.
+const DefaultStatusPath = "/status"
 
-	return &event, nil
-}
+// nextPollInterval is how often Next retries Store.Dequeue while waiting
+// for a new delivery to show up.
+const nextPollInterval = 50 * time.Millisecond
 
-func (e *Event) String() (output string) {
-	output += "type:   " + e.Type + "\n"
-	output += "owner:  " + e.Owner + "\n"
-	output += "repo:   " + e.Repo + "\n"
-	output += "branch: " + e.Branch + "\n"
-	output += "commit: " + e.Commit + "\n"
-
-	if e.Type == "pull_request" {
-		output += "action: " + e.Action + "\n"
-		output += "bowner: " + e.BaseOwner + "\n"
-		output += "brepo:  " + e.BaseRepo + "\n"
-		output += "bbranch:" + e.BaseBranch + "\n"
-	}
+// ErrNoStore is returned by Next when called on a Server with no Store
+// configured.
+var ErrNoStore = errors.New("hookserve: Server.Next requires Server.Store to be set")
 
-	return
-}
-
-type Server struct {
-	Port       int        // Port to listen on. Defaults to 80
-	Path       string     // Path to receive on. Defaults to "/postreceive"
-	Secret     string     // Option secret key for authenticating via HMAC
-	IgnoreTags bool       // If set to false, also execute command if tag is pushed
-	Events     chan Event // Channel of events. Read from this channel to get push events as they happen.
-}
+// Event is the envelope delivered on Server.Events for every accepted
+// webhook. Payload holds the decoded payload for Type -- for the github
+// Provider, type-switch on it (events.PushEvent, events.PullRequestEvent,
+// and so on) to get at the event's structured data.
+type Event = providers.Event
 
 // Create a new server with sensible defaults.
-// By default the Port is set to 80 and the Path is set to `/postreceive`
+// By default the Port is set to 80, the Path is set to `/postreceive`, and
+// the only configured Provider is GitHub.
 func NewServer() *Server {
 	return &Server{
-		Port:       80,
-		Path:       "/postreceive",
-		IgnoreTags: true,
-		Events:     make(chan Event, 10), // buffered to 10 items
+		Port:          80,
+		Path:          "/postreceive",
+		StatusPath:    DefaultStatusPath,
+		IgnoreTags:    true,
+		EventTypes:    DefaultEventTypes,
+		Providers:     []providers.Provider{github.New()},
+		DeliveryStore: deliverystore.NewLRU(DefaultDeliveryStoreSize, DefaultDeliveryTTL),
+		Events:        make(chan Event, 10), // buffered to 10 items
 	}
 }
 
+type Server struct {
+	Port          int                      // Port to listen on. Defaults to 80
+	Path          string                   // Path to receive on. Defaults to "/postreceive"
+	StatusPath    string                   // Path to report Forwards delivery outcomes on. Defaults to DefaultStatusPath
+	Secret        string                   // Option secret key for authenticating via HMAC
+	AllowSHA1     bool                     // If set to true, allow falling back to a provider's legacy SHA-1 signature when no stronger one is present
+	IgnoreTags    bool                     // If set to false, also execute command if tag is pushed
+	EventTypes    []string                 // Event types to accept. Defaults to DefaultEventTypes (push, pull_request)
+	Providers     []providers.Provider     // Forges to accept webhooks from. Defaults to just github.New()
+	DeliveryStore deliverystore.Store      // Tracks delivery IDs to reject replayed webhooks. Defaults to an in-memory LRU; set to nil to disable
+	Forwards      []*forward.ForwardTarget // Local URLs to re-post validated deliveries to, for use as a dev-side relay
+	Store         store.Store              // If set, persists each delivery before it's acknowledged to the sender; consume via Next instead of Events
+	Events        chan Event               // Channel of events. Read from this channel to get events of the accepted EventTypes as they happen. Unused when Store is set.
+}
+
 // Spin up the server and listen for github webhook push events. The events will be passed to Server.Events channel.
 func (s *Server) ListenAndServe() error {
 	return http.ListenAndServe(":"+strconv.Itoa(s.Port), s)
@@ -122,20 +92,93 @@ func (s *Server) GoListenAndServe() {
 	}()
 }
 
+// Next returns the oldest unacked event from s.Store, blocking until one
+// is available or ctx is done. The returned ack must be called once the
+// event has been fully processed; until then Next does not hand the event
+// out again, but it becomes eligible again if this process restarts
+// before ack is called. Next returns ErrNoStore if s.Store is nil.
+func (s *Server) Next(ctx context.Context) (Event, func() error, error) {
+	if s.Store == nil {
+		return Event{}, nil, ErrNoStore
+	}
+
+	for {
+		event, ack, err := s.Store.Dequeue()
+		if err == nil {
+			return event, ack, nil
+		}
+		if err != store.ErrEmpty {
+			return Event{}, nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return Event{}, nil, ctx.Err()
+		case <-time.After(nextPollInterval):
+		}
+	}
+}
+
 // Checks if the given ref should be ignored
 func (s *Server) ignoreRef(rawRef string) bool {
+	// A push payload's ref is expected to be "refs/heads/<branch>" or
+	// "refs/tags/<tag>"; anything shorter isn't a ref we understand, so
+	// treat it as ignored rather than panic on the slices below.
+	if len(rawRef) < 11 {
+		return true
+	}
 	if rawRef[:10] == "refs/tags/" && !s.IgnoreTags {
 		return false
 	}
 	return rawRef[:11] != "refs/heads/"
 }
 
+// eventAllowed reports whether eventType is in s.EventTypes, falling back
+// to DefaultEventTypes when EventTypes is unset.
+func (s *Server) eventAllowed(eventType string) bool {
+	allowed := s.EventTypes
+	if allowed == nil {
+		allowed = DefaultEventTypes
+	}
+	for _, t := range allowed {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// statusPath returns s.StatusPath, falling back to DefaultStatusPath when
+// unset.
+func (s *Server) statusPath() string {
+	if s.StatusPath == "" {
+		return DefaultStatusPath
+	}
+	return s.StatusPath
+}
+
+// provider returns the first configured Provider that recognizes req, or
+// nil if none do.
+func (s *Server) provider(req *http.Request) providers.Provider {
+	for _, p := range s.Providers {
+		if p.Detect(req) {
+			return p
+		}
+	}
+	return nil
+}
+
 // Satisfies the http.Handler interface.
 // Instead of calling Server.ListenAndServe you can integrate hookserve.Server inside your own http server.
 // If you are using hookserve.Server in his way Server.Path should be set to match your mux pattern and Server.Port will be ignored.
 func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
 
+	if req.Method == "GET" && req.URL.Path == s.statusPath() {
+		forward.WriteStatus(w, s.Forwards)
+		return
+	}
+
 	if req.Method != "POST" {
 		http.Error(w, "405 Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -145,13 +188,19 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	eventType := req.Header.Get("X-GitHub-Event")
+	provider := s.provider(req)
+	if provider == nil {
+		http.Error(w, "400 Bad Request - Unrecognized webhook provider", http.StatusBadRequest)
+		return
+	}
+
+	eventType := provider.EventType(req)
 	if eventType == "" {
-		http.Error(w, "400 Bad Request - Missing X-GitHub-Event Header", http.StatusBadRequest)
+		http.Error(w, "400 Bad Request - Missing event type header", http.StatusBadRequest)
 		return
 	}
-	if eventType != "push" && eventType != "pull_request" {
-		http.Error(w, "400 Bad Request - Unknown Event Type "+eventType, http.StatusBadRequest)
+	if !s.eventAllowed(eventType) {
+		http.Error(w, "400 Bad Request - Unhandled Event Type "+eventType, http.StatusBadRequest)
 		return
 	}
 
@@ -161,117 +210,62 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// If we have a Secret set, we should check the MAC
-	if s.Secret != "" {
-		sig := req.Header.Get("X-Hub-Signature")
+	opts := providers.VerifyOptions{AllowSHA1: s.AllowSHA1}
+	if err := provider.Verify(body, req, s.Secret, opts); err != nil {
+		http.Error(w, "403 Forbidden - "+err.Error(), http.StatusForbidden)
+		return
+	}
 
-		if sig == "" {
-			http.Error(w, "403 Forbidden - Missing X-Hub-Signature required for HMAC verification", http.StatusForbidden)
-			return
-		}
+	deliveryID := provider.DeliveryID(req)
+	if s.DeliveryStore != nil && s.DeliveryStore.Seen(deliveryID) {
+		http.Error(w, "409 Conflict - Duplicate delivery "+deliveryID, http.StatusConflict)
+		return
+	}
 
-		mac := hmac.New(sha1.New, []byte(s.Secret))
-		mac.Write(body)
-		expectedMAC := mac.Sum(nil)
-		expectedSig := "sha1=" + hex.EncodeToString(expectedMAC)
-		if !hmac.Equal([]byte(expectedSig), []byte(sig)) {
-			http.Error(w, "403 Forbidden - HMAC verification failed", http.StatusForbidden)
-			return
-		}
+	for _, t := range s.Forwards {
+		t.Forward(eventType, deliveryID, req.Header, body)
 	}
 
-	request := jsontree.New()
-	err = request.UnmarshalJSON(body)
+	event, err := provider.Parse(eventType, body)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "400 Bad Request - "+err.Error(), http.StatusBadRequest)
 		return
 	}
+	event.DeliveryID = deliveryID
 
-	// Parse the request and build the Event
-	event := Event{}
-
-	if eventType == "push" {
-		rawRef, err := request.Get("ref").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		// If the ref is not a branch, we don't care about it
-		if s.ignoreRef(rawRef) || request.Get("head_commit").IsNull() {
-			return
-		}
-
-		// Fill in values
-		event.Type = eventType
-		event.Branch = rawRef[11:]
-		event.Repo, err = request.Get("repository").Get("name").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		event.Commit, err = request.Get("head_commit").Get("id").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		event.Owner, err = request.Get("repository").Get("owner").Get("name").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	} else if eventType == "pull_request" {
-		event.Action, err = request.Get("action").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	// Pushes to anything but a branch (or a tag, if IgnoreTags is false) are
+	// not interesting to hookserve's consumers
+	if push, ok := event.Payload.(*events.PushEvent); ok {
+		if s.ignoreRef(push.Ref) || push.HeadCommit == nil {
+			if s.DeliveryStore != nil {
+				s.DeliveryStore.Record(deliveryID)
+			}
 			return
 		}
+	}
 
-		// Fill in values
-		event.Type = eventType
-		event.Owner, err = request.Get("pull_request").Get("head").Get("repo").Get("owner").Get("login").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		event.Repo, err = request.Get("pull_request").Get("head").Get("repo").Get("name").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		event.Branch, err = request.Get("pull_request").Get("head").Get("ref").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		event.Commit, err = request.Get("pull_request").Get("head").Get("sha").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		event.BaseOwner, err = request.Get("pull_request").Get("base").Get("repo").Get("owner").Get("login").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		event.BaseRepo, err = request.Get("pull_request").Get("base").Get("repo").Get("name").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		event.BaseBranch, err = request.Get("pull_request").Get("base").Get("ref").String()
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	// We've built our Event. If a Store is configured, persist it before
+	// acknowledging the delivery, so a crash before a consumer acks it
+	// just means GitHub's retry (or our own restart recovery) hands it
+	// out again. Otherwise fall back to the old best-effort channel. Only
+	// once that's done do we record the delivery as seen -- if Enqueue
+	// fails and we never reach here, a redelivery of the same GUID must
+	// still get through.
+	if s.Store != nil {
+		if err := s.Store.Enqueue(event); err != nil {
+			http.Error(w, "500 Internal Server Error - "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 	} else {
-		http.Error(w, "Unknown Event Type "+eventType, http.StatusInternalServerError)
-		return
+		go func() {
+			s.Events <- event
+		}()
 	}
 
-	// We've built our Event - put it into the channel and we're done
-	go func() {
-		s.Events <- event
-	}()
+	if s.DeliveryStore != nil {
+		s.DeliveryStore.Record(deliveryID)
+	}
 
-	w.Write([]byte(event.String()))
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("202 Accepted - " + eventType + " " + event.DeliveryID))
 }
@@ -0,0 +1,60 @@
+// Package bitbucket implements the hookserve Provider interface for
+// Bitbucket's webhook conventions (X-Event-Key, X-Request-UUID).
+package bitbucket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/phayes/hookserve/hookserve/providers"
+)
+
+// Provider implements providers.Provider for Bitbucket.
+type Provider struct{}
+
+// New returns a Bitbucket Provider.
+func New() *Provider {
+	return &Provider{}
+}
+
+func (p *Provider) Name() string {
+	return "bitbucket"
+}
+
+func (p *Provider) Detect(req *http.Request) bool {
+	return req.Header.Get("X-Event-Key") != ""
+}
+
+// EventType normalizes Bitbucket's "repo:push" style event key into
+// "repo_push" to match the rest of hookserve's naming.
+func (p *Provider) EventType(req *http.Request) string {
+	return strings.ReplaceAll(req.Header.Get("X-Event-Key"), ":", "_")
+}
+
+func (p *Provider) DeliveryID(req *http.Request) string {
+	return req.Header.Get("X-Request-UUID")
+}
+
+// Verify always fails when a secret is configured: Bitbucket Cloud webhooks
+// have no shared-secret or signature mechanism, so there is nothing to
+// check the payload against. Leave Server.Secret unset for this provider
+// and rely on the obscurity of the endpoint URL or IP allow-listing
+// instead.
+func (p *Provider) Verify(body []byte, req *http.Request, secret string, opts providers.VerifyOptions) error {
+	if secret == "" {
+		return nil
+	}
+	return providers.ErrUnsupportedVerification
+}
+
+// Parse decodes body as generic JSON. Bitbucket payloads aren't yet
+// modeled as typed structs the way GitHub's are; callers get a
+// map[string]interface{}.
+func (p *Provider) Parse(eventType string, body []byte) (providers.Event, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return providers.Event{}, err
+	}
+	return providers.Event{Type: eventType, Payload: payload}, nil
+}
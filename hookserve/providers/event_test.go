@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phayes/hookserve/hookserve/providers/github/events"
+)
+
+const pushBody = `{
+	"ref": "refs/heads/main",
+	"head_commit": {"id": "abc123"},
+	"repository": {"name": "repo", "owner": {"login": "octocat"}}
+}`
+
+const pullRequestBody = `{
+	"action": "opened",
+	"number": 1,
+	"pull_request": {
+		"number": 1,
+		"state": "open",
+		"head": {"ref": "feature", "sha": "s1", "repo": {"name": "repo", "owner": {"login": "octocat"}}},
+		"base": {"ref": "main", "sha": "s2", "repo": {"name": "repo", "owner": {"login": "octocat"}}}
+	},
+	"repository": {"name": "repo", "owner": {"login": "octocat"}}
+}`
+
+func mustParse(t *testing.T, eventType, body string) Event {
+	t.Helper()
+	payload, err := events.Parse(eventType, []byte(body))
+	if err != nil {
+		t.Fatalf("events.Parse(%q): %v", eventType, err)
+	}
+	return Event{DeliveryID: "d-" + eventType, Type: eventType, Payload: payload}
+}
+
+func TestEventTextRoundTrip(t *testing.T) {
+	for _, eventType := range []string{"push", "pull_request"} {
+		body := pushBody
+		if eventType == "pull_request" {
+			body = pullRequestBody
+		}
+		want := mustParse(t, eventType, body)
+
+		text, err := want.MarshalText()
+		if err != nil {
+			t.Fatalf("%s: MarshalText: %v", eventType, err)
+		}
+
+		var got Event
+		if err := got.UnmarshalText(text); err != nil {
+			t.Fatalf("%s: UnmarshalText: %v", eventType, err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("%s: round trip mismatch\n want: %#v\n got:  %#v", eventType, want, got)
+		}
+	}
+}
+
+func TestEventJSONRoundTrip(t *testing.T) {
+	for _, eventType := range []string{"push", "pull_request"} {
+		body := pushBody
+		if eventType == "pull_request" {
+			body = pullRequestBody
+		}
+		want := mustParse(t, eventType, body)
+
+		data, err := want.MarshalJSON()
+		if err != nil {
+			t.Fatalf("%s: MarshalJSON: %v", eventType, err)
+		}
+
+		var got Event
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Fatalf("%s: UnmarshalJSON: %v", eventType, err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("%s: round trip mismatch\n want: %#v\n got:  %#v", eventType, want, got)
+		}
+	}
+}
+
+func TestPullRequestEventPreservesAction(t *testing.T) {
+	want := mustParse(t, "pull_request", pullRequestBody)
+
+	text, err := want.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText: %v", err)
+	}
+
+	var got Event
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+
+	pr, ok := got.Payload.(*events.PullRequestEvent)
+	if !ok {
+		t.Fatalf("Payload is %T, want *events.PullRequestEvent", got.Payload)
+	}
+	if pr.Action != "opened" {
+		t.Fatalf("Action = %q, want %q", pr.Action, "opened")
+	}
+}
+
+func TestUnmarshalTextRejectsUnknownVersion(t *testing.T) {
+	var e Event
+	err := e.UnmarshalText([]byte("hookserve/v2\ntype: push\n"))
+	if err != ErrInvalidWireFormat {
+		t.Fatalf("err = %v, want ErrInvalidWireFormat", err)
+	}
+}
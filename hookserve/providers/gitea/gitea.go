@@ -0,0 +1,66 @@
+// Package gitea implements the hookserve Provider interface for Gitea's
+// webhook conventions (X-Gitea-Event, HMAC-SHA256 in X-Gitea-Signature).
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/phayes/hookserve/hookserve/providers"
+)
+
+// Provider implements providers.Provider for Gitea.
+type Provider struct{}
+
+// New returns a Gitea Provider.
+func New() *Provider {
+	return &Provider{}
+}
+
+func (p *Provider) Name() string {
+	return "gitea"
+}
+
+func (p *Provider) Detect(req *http.Request) bool {
+	return req.Header.Get("X-Gitea-Event") != ""
+}
+
+func (p *Provider) EventType(req *http.Request) string {
+	return req.Header.Get("X-Gitea-Event")
+}
+
+func (p *Provider) DeliveryID(req *http.Request) string {
+	return req.Header.Get("X-Gitea-Delivery")
+}
+
+func (p *Provider) Verify(body []byte, req *http.Request, secret string, opts providers.VerifyOptions) error {
+	if secret == "" {
+		return nil
+	}
+
+	sig := req.Header.Get("X-Gitea-Signature")
+	if sig == "" {
+		return providers.ErrMissingSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expectedSig := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expectedSig), []byte(sig)) {
+		return providers.ErrInvalidSignature
+	}
+	return nil
+}
+
+// Parse decodes body as generic JSON. Gitea payloads aren't yet modeled as
+// typed structs the way GitHub's are; callers get a map[string]interface{}.
+func (p *Provider) Parse(eventType string, body []byte) (providers.Event, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return providers.Event{}, err
+	}
+	return providers.Event{Type: eventType, Payload: payload}, nil
+}
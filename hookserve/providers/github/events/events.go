@@ -0,0 +1,202 @@
+// Package events contains typed payload structs for the GitHub webhook
+// event types that hookserve understands. Each struct mirrors the subset of
+// fields GitHub documents for that event -- enough to drive a deploy
+// pipeline without forcing consumers to re-parse the raw JSON themselves.
+package events
+
+// User is the GitHub actor embedded in most webhook payloads (a repository
+// owner, a PR author, a commenter, etc).
+type User struct {
+	Login string `json:"login"`
+	ID    int64  `json:"id"`
+}
+
+// Repository is the repository a webhook event was fired for.
+type Repository struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Private  bool   `json:"private"`
+	Owner    User   `json:"owner"`
+}
+
+// Commit is a single commit as it appears in a PushEvent's Commits list.
+type Commit struct {
+	ID        string `json:"id"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+	Author    User   `json:"author"`
+}
+
+// PushEvent is sent for the "push" webhook event.
+type PushEvent struct {
+	Ref        string     `json:"ref"`
+	Before     string     `json:"before"`
+	After      string     `json:"after"`
+	Created    bool       `json:"created"`
+	Deleted    bool       `json:"deleted"`
+	Forced     bool       `json:"forced"`
+	Commits    []Commit   `json:"commits"`
+	HeadCommit *Commit    `json:"head_commit"`
+	Repository Repository `json:"repository"`
+	Pusher     struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"pusher"`
+	Sender User `json:"sender"`
+}
+
+// PullRequestBranch describes the head or base side of a pull request.
+type PullRequestBranch struct {
+	Ref  string     `json:"ref"`
+	SHA  string     `json:"sha"`
+	Repo Repository `json:"repo"`
+}
+
+// PullRequest is the pull request object embedded in PullRequestEvent and
+// PullRequestReviewEvent.
+type PullRequest struct {
+	Number int               `json:"number"`
+	State  string            `json:"state"`
+	Title  string            `json:"title"`
+	Merged bool              `json:"merged"`
+	User   User              `json:"user"`
+	Head   PullRequestBranch `json:"head"`
+	Base   PullRequestBranch `json:"base"`
+}
+
+// PullRequestEvent is sent for the "pull_request" webhook event.
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+	Sender      User        `json:"sender"`
+}
+
+// Review is the review object embedded in a PullRequestReviewEvent.
+type Review struct {
+	ID    int64  `json:"id"`
+	Body  string `json:"body"`
+	State string `json:"state"`
+	User  User   `json:"user"`
+}
+
+// PullRequestReviewEvent is sent for the "pull_request_review" webhook event.
+type PullRequestReviewEvent struct {
+	Action      string      `json:"action"`
+	Review      Review      `json:"review"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+	Sender      User        `json:"sender"`
+}
+
+// Issue is the issue object embedded in IssuesEvent and IssueCommentEvent.
+type Issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Body   string `json:"body"`
+	User   User   `json:"user"`
+}
+
+// IssuesEvent is sent for the "issues" webhook event.
+type IssuesEvent struct {
+	Action     string     `json:"action"`
+	Issue      Issue      `json:"issue"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// Comment is the comment object embedded in an IssueCommentEvent.
+type Comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	User User   `json:"user"`
+}
+
+// IssueCommentEvent is sent for the "issue_comment" webhook event.
+type IssueCommentEvent struct {
+	Action     string     `json:"action"`
+	Issue      Issue      `json:"issue"`
+	Comment    Comment    `json:"comment"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// Release is the release object embedded in a ReleaseEvent.
+type Release struct {
+	ID         int64  `json:"id"`
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// ReleaseEvent is sent for the "release" webhook event.
+type ReleaseEvent struct {
+	Action     string     `json:"action"`
+	Release    Release    `json:"release"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// CreateEvent is sent for the "create" webhook event (branch or tag created).
+type CreateEvent struct {
+	Ref          string     `json:"ref"`
+	RefType      string     `json:"ref_type"`
+	MasterBranch string     `json:"master_branch"`
+	Description  string     `json:"description"`
+	Repository   Repository `json:"repository"`
+	Sender       User       `json:"sender"`
+}
+
+// DeleteEvent is sent for the "delete" webhook event (branch or tag deleted).
+type DeleteEvent struct {
+	Ref        string     `json:"ref"`
+	RefType    string     `json:"ref_type"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// PingEvent is sent once when a webhook is first configured.
+type PingEvent struct {
+	Zen        string     `json:"zen"`
+	HookID     int64      `json:"hook_id"`
+	Repository Repository `json:"repository"`
+}
+
+// CheckRun is the check run object embedded in a CheckRunEvent.
+type CheckRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HeadSHA    string `json:"head_sha"`
+}
+
+// CheckRunEvent is sent for the "check_run" webhook event.
+type CheckRunEvent struct {
+	Action     string     `json:"action"`
+	CheckRun   CheckRun   `json:"check_run"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// WorkflowRun is the workflow run object embedded in a WorkflowRunEvent.
+type WorkflowRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HeadBranch string `json:"head_branch"`
+	HeadSHA    string `json:"head_sha"`
+}
+
+// WorkflowRunEvent is sent for the "workflow_run" webhook event.
+type WorkflowRunEvent struct {
+	Action      string      `json:"action"`
+	WorkflowRun WorkflowRun `json:"workflow_run"`
+	Repository  Repository  `json:"repository"`
+	Sender      User        `json:"sender"`
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrUnknownEventType is returned by Parse when eventType doesn't match any
+// of the GitHub event types hookserve knows how to decode.
+var ErrUnknownEventType = errors.New("events: unknown event type")
+
+// Parse unmarshals a raw webhook body into the typed payload for eventType,
+// the value of the X-GitHub-Event header. The returned value's concrete
+// type depends on eventType: "push" yields *PushEvent, "pull_request"
+// yields *PullRequestEvent, and so on. Callers type-switch on the result to
+// get at the structured fields.
+func Parse(eventType string, body []byte) (interface{}, error) {
+	payload, ok := newPayload(eventType)
+	if !ok {
+		return nil, ErrUnknownEventType
+	}
+	if err := json.Unmarshal(body, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func newPayload(eventType string) (interface{}, bool) {
+	switch eventType {
+	case "push":
+		return &PushEvent{}, true
+	case "pull_request":
+		return &PullRequestEvent{}, true
+	case "pull_request_review":
+		return &PullRequestReviewEvent{}, true
+	case "issues":
+		return &IssuesEvent{}, true
+	case "issue_comment":
+		return &IssueCommentEvent{}, true
+	case "release":
+		return &ReleaseEvent{}, true
+	case "create":
+		return &CreateEvent{}, true
+	case "delete":
+		return &DeleteEvent{}, true
+	case "ping":
+		return &PingEvent{}, true
+	case "check_run":
+		return &CheckRunEvent{}, true
+	case "workflow_run":
+		return &WorkflowRunEvent{}, true
+	default:
+		return nil, false
+	}
+}
+
+// Known reports whether eventType is one Parse can decode.
+func Known(eventType string) bool {
+	_, ok := newPayload(eventType)
+	return ok
+}
@@ -0,0 +1,80 @@
+// Package github implements the hookserve Provider interface for GitHub's
+// webhook conventions (X-GitHub-Event, X-GitHub-Delivery, X-Hub-Signature).
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+
+	"github.com/phayes/hookserve/hookserve/providers"
+	"github.com/phayes/hookserve/hookserve/providers/github/events"
+)
+
+// Provider implements providers.Provider for GitHub.
+type Provider struct{}
+
+// New returns a GitHub Provider.
+func New() *Provider {
+	return &Provider{}
+}
+
+func (p *Provider) Name() string {
+	return "github"
+}
+
+func (p *Provider) Detect(req *http.Request) bool {
+	return req.Header.Get("X-GitHub-Event") != ""
+}
+
+func (p *Provider) EventType(req *http.Request) string {
+	return req.Header.Get("X-GitHub-Event")
+}
+
+func (p *Provider) DeliveryID(req *http.Request) string {
+	return req.Header.Get("X-GitHub-Delivery")
+}
+
+// Verify prefers the SHA-256 signature in X-Hub-Signature-256. It only
+// falls back to the legacy SHA-1 signature in X-Hub-Signature when opts
+// explicitly allows it, since SHA-1 is vulnerable to collision attacks.
+func (p *Provider) Verify(body []byte, req *http.Request, secret string, opts providers.VerifyOptions) error {
+	if secret == "" {
+		return nil
+	}
+
+	if sig := req.Header.Get("X-Hub-Signature-256"); sig != "" {
+		return checkHMAC(body, secret, sig, "sha256=", sha256.New)
+	}
+
+	if !opts.AllowSHA1 {
+		return providers.ErrMissingSignature
+	}
+
+	sig := req.Header.Get("X-Hub-Signature")
+	if sig == "" {
+		return providers.ErrMissingSignature
+	}
+	return checkHMAC(body, secret, sig, "sha1=", sha1.New)
+}
+
+func checkHMAC(body []byte, secret, sig, prefix string, newHash func() hash.Hash) error {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	expectedSig := prefix + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expectedSig), []byte(sig)) {
+		return providers.ErrInvalidSignature
+	}
+	return nil
+}
+
+func (p *Provider) Parse(eventType string, body []byte) (providers.Event, error) {
+	payload, err := events.Parse(eventType, body)
+	if err != nil {
+		return providers.Event{}, err
+	}
+	return providers.Event{Type: eventType, Payload: payload}, nil
+}
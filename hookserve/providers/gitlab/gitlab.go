@@ -0,0 +1,70 @@
+// Package gitlab implements the hookserve Provider interface for GitLab's
+// webhook conventions (X-Gitlab-Event, X-Gitlab-Token).
+package gitlab
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/phayes/hookserve/hookserve/providers"
+)
+
+// Provider implements providers.Provider for GitLab.
+type Provider struct{}
+
+// New returns a GitLab Provider.
+func New() *Provider {
+	return &Provider{}
+}
+
+func (p *Provider) Name() string {
+	return "gitlab"
+}
+
+func (p *Provider) Detect(req *http.Request) bool {
+	return req.Header.Get("X-Gitlab-Event") != ""
+}
+
+// EventType normalizes GitLab's "Push Hook" / "Merge Request Hook" style
+// header into the lower_snake_case form the rest of hookserve uses, eg.
+// "push" and "merge_request".
+func (p *Provider) EventType(req *http.Request) string {
+	header := req.Header.Get("X-Gitlab-Event")
+	header = strings.TrimSuffix(header, " Hook")
+	header = strings.ToLower(header)
+	return strings.ReplaceAll(header, " ", "_")
+}
+
+func (p *Provider) DeliveryID(req *http.Request) string {
+	return ""
+}
+
+// Verify compares X-Gitlab-Token against secret. Unlike GitHub's HMAC
+// signature, GitLab sends the shared secret itself, so there is no body to
+// sign -- we just need a constant-time comparison.
+func (p *Provider) Verify(body []byte, req *http.Request, secret string, opts providers.VerifyOptions) error {
+	if secret == "" {
+		return nil
+	}
+
+	token := req.Header.Get("X-Gitlab-Token")
+	if token == "" {
+		return providers.ErrMissingSignature
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return providers.ErrInvalidSignature
+	}
+	return nil
+}
+
+// Parse decodes body as generic JSON. GitLab payloads aren't yet modeled as
+// typed structs the way GitHub's are; callers get a map[string]interface{}.
+func (p *Provider) Parse(eventType string, body []byte) (providers.Event, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return providers.Event{}, err
+	}
+	return providers.Event{Type: eventType, Payload: payload}, nil
+}
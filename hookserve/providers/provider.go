@@ -0,0 +1,224 @@
+// Package providers defines the interface hookserve uses to talk to a
+// specific forge's webhook conventions, along with the envelope Parse
+// produces.
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/phayes/hookserve/hookserve/providers/github/events"
+)
+
+// ErrMissingSignature is returned by Verify when a secret is configured but
+// the request carries none of the headers a provider signs its payload with.
+var ErrMissingSignature = errors.New("providers: missing signature header")
+
+// ErrInvalidSignature is returned by Verify when the signature present on
+// the request does not match the body and secret.
+var ErrInvalidSignature = errors.New("providers: signature verification failed")
+
+// ErrUnsupportedVerification is returned by Verify when a secret is
+// configured but the provider has no way to authenticate deliveries.
+var ErrUnsupportedVerification = errors.New("providers: this provider does not support shared-secret verification")
+
+// VerifyOptions carries Server-level verification settings down to a
+// Provider's Verify method. Providers that have no use for a given option
+// simply ignore it.
+type VerifyOptions struct {
+	// AllowSHA1 permits falling back to a provider's legacy SHA-1 HMAC
+	// signature scheme when no stronger signature is present on the
+	// request.
+	AllowSHA1 bool
+}
+
+// Event is the envelope a Provider produces for each accepted webhook
+// delivery.
+type Event struct {
+	DeliveryID string      // A provider-supplied GUID identifying this delivery, if any
+	Type       string      // The normalized event type, eg. "push" or "pull_request"
+	Payload    interface{} // The decoded payload for Type
+}
+
+// WireVersion identifies the text/JSON format Event's
+// MarshalText/UnmarshalText and MarshalJSON/UnmarshalJSON use to move an
+// Event between processes -- a spool file, a pipe to another command, and
+// so on.
+//
+// This supersedes the flat, pre-Provider Event's exported NewEvent and
+// String() -- both of which are gone now that Event carries a typed
+// Payload instead of Owner/Repo/Branch/Commit/Action fields. Callers that
+// piped the old "key: value" String() output to another process should
+// switch to MarshalText/UnmarshalText, which round-trip Event losslessly
+// instead of re-deriving a handful of its fields.
+const WireVersion = "hookserve/v1"
+
+// ErrInvalidWireFormat is returned by UnmarshalText when data doesn't start
+// with a WireVersion header line, and by UnmarshalJSON when data carries a
+// version other than WireVersion. UnmarshalJSON accepts data with no
+// version field at all.
+var ErrInvalidWireFormat = errors.New("providers: invalid or unrecognized event wire format")
+
+// MarshalText encodes e as a WireVersion header line followed by
+// "key: value" pairs, one per line, suitable for piping Events between
+// processes. UnmarshalText parses the same format back.
+func (e Event) MarshalText() ([]byte, error) {
+	payload, err := json.Marshal(e.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, WireVersion)
+	fmt.Fprintln(&buf, "delivery_id:", e.DeliveryID)
+	fmt.Fprintln(&buf, "type:", e.Type)
+	fmt.Fprintln(&buf, "payload:", string(payload))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalText parses the format produced by MarshalText, tolerant of
+// surrounding whitespace, blank lines, and unknown extra fields, so it
+// stays forward compatible with future fields added under the same
+// WireVersion. Payload is decoded into the typed struct the github/events
+// package would have produced for Type when Type is a known GitHub event
+// type -- mirroring what a live github.Provider.Parse returns -- and
+// falls back to a generic map[string]interface{} otherwise.
+func (e *Event) UnmarshalText(data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// The payload is a single line, so allow lines as long as data itself.
+	scanner.Buffer(nil, len(data)+1)
+	if !scanner.Scan() {
+		return ErrInvalidWireFormat
+	}
+	if strings.TrimSpace(scanner.Text()) != WireVersion {
+		return ErrInvalidWireFormat
+	}
+
+	fields := make(map[string]string)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return e.fromFields(fields)
+}
+
+// eventWire is the JSON shape MarshalJSON/UnmarshalJSON (de)serialize an
+// Event to/from -- the same fields MarshalText uses, just JSON instead of
+// line-oriented.
+type eventWire struct {
+	Version    string          `json:"version"`
+	DeliveryID string          `json:"delivery_id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// MarshalJSON implements json.Marshaler, tagging the output with
+// WireVersion.
+func (e Event) MarshalJSON() ([]byte, error) {
+	payload, err := json.Marshal(e.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(eventWire{
+		Version:    WireVersion,
+		DeliveryID: e.DeliveryID,
+		Type:       e.Type,
+		Payload:    payload,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for the format MarshalJSON
+// produces, decoding Payload the same way UnmarshalText does.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var wire eventWire
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	if wire.Version != "" && wire.Version != WireVersion {
+		return ErrInvalidWireFormat
+	}
+
+	e.DeliveryID = wire.DeliveryID
+	e.Type = wire.Type
+	if len(wire.Payload) == 0 {
+		e.Payload = nil
+		return nil
+	}
+	return e.decodePayload(wire.Payload)
+}
+
+func (e *Event) fromFields(fields map[string]string) error {
+	e.DeliveryID = fields["delivery_id"]
+	e.Type = fields["type"]
+
+	payload, ok := fields["payload"]
+	if !ok || payload == "" {
+		e.Payload = nil
+		return nil
+	}
+	return e.decodePayload([]byte(payload))
+}
+
+// decodePayload fills e.Payload from raw, preferring the typed struct
+// github/events.Parse produces for e.Type and falling back to a generic
+// map[string]interface{} for event types it doesn't know about (eg.
+// providers other than GitHub, which decode payloads generically to
+// begin with).
+func (e *Event) decodePayload(raw []byte) error {
+	if typed, err := events.Parse(e.Type, raw); err == nil {
+		e.Payload = typed
+		return nil
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return err
+	}
+	e.Payload = generic
+	return nil
+}
+
+// Provider knows how to recognize, authenticate, and decode webhooks from a
+// single forge (GitHub, GitLab, Gitea, Bitbucket, ...). Server tries each
+// configured Provider's Detect in turn and dispatches to the first match,
+// so a single endpoint can serve webhooks from multiple forges.
+type Provider interface {
+	// Name identifies the provider, eg. "github".
+	Name() string
+
+	// Detect reports whether req looks like a webhook delivery from this
+	// provider, based on its headers.
+	Detect(req *http.Request) bool
+
+	// EventType returns the provider-specific event type for req, eg. the
+	// value of X-GitHub-Event or X-Gitlab-Event.
+	EventType(req *http.Request) string
+
+	// DeliveryID returns a provider-supplied delivery identifier for req,
+	// or "" if the provider doesn't send one.
+	DeliveryID(req *http.Request) string
+
+	// Verify authenticates body against the signature headers on req using
+	// secret. An empty secret means verification should be skipped.
+	Verify(body []byte, req *http.Request, secret string, opts VerifyOptions) error
+
+	// Parse decodes body into the typed or generic payload for eventType
+	// and returns the resulting Event.
+	Parse(eventType string, body []byte) (Event, error)
+}
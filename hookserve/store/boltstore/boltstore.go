@@ -0,0 +1,113 @@
+// Package boltstore is a store.Store backed by a bbolt file, for
+// deployments that would rather not spool individual files to disk.
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+
+	"github.com/phayes/hookserve/hookserve/store"
+	"go.etcd.io/bbolt"
+)
+
+var bucketName = []byte("queue")
+
+// BoltStore is a store.Store backed by a single bbolt.DB bucket, keyed by a
+// monotonically increasing sequence number so Dequeue can always find the
+// oldest pending delivery with a forward cursor scan.
+type BoltStore struct {
+	db *bbolt.DB
+
+	mu     sync.Mutex
+	leased map[uint64]bool
+}
+
+// Open returns a BoltStore backed by the bbolt.DB file at path, creating it
+// if necessary.
+func Open(path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db, leased: make(map[uint64]bool)}, nil
+}
+
+// Close releases the underlying bbolt.DB file.
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
+
+// Enqueue implements store.Store by writing event under the bucket's next
+// sequence number inside a single bolt transaction, so Enqueue only
+// returns once the write is fsynced to disk.
+func (b *BoltStore) Enqueue(event store.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(seqKey(seq), body)
+	})
+}
+
+// Dequeue implements store.Store by returning the lowest-keyed entry not
+// already leased to another caller. ack deletes the entry from the bucket.
+func (b *BoltStore) Dequeue() (store.Event, func() error, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var key uint64
+	var body []byte
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(bucketName).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			seq := binary.BigEndian.Uint64(k)
+			if b.leased[seq] {
+				continue
+			}
+			key, body = seq, append([]byte(nil), v...)
+			return nil
+		}
+		return store.ErrEmpty
+	})
+	if err != nil {
+		return store.Event{}, nil, err
+	}
+
+	var event store.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return store.Event{}, nil, err
+	}
+
+	b.leased[key] = true
+	ack := func() error {
+		b.mu.Lock()
+		delete(b.leased, key)
+		b.mu.Unlock()
+		return b.db.Update(func(tx *bbolt.Tx) error {
+			return tx.Bucket(bucketName).Delete(seqKey(key))
+		})
+	}
+	return event, ack, nil
+}
+
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
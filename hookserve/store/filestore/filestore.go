@@ -0,0 +1,137 @@
+// Package filestore is a store.Store backed by a spool directory: one JSON
+// file per pending delivery, renamed into a "done" subdirectory on ack
+// instead of being deleted, so the spool doubles as an audit trail.
+package filestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/phayes/hookserve/hookserve/store"
+)
+
+const doneDir = "done"
+
+// FileStore is a store.Store that spools deliveries as JSON files under a
+// directory on disk.
+type FileStore struct {
+	dir string
+
+	mu     sync.Mutex
+	seq    uint64
+	leased map[string]bool
+}
+
+// Open returns a FileStore spooling into dir, creating dir and its "done"
+// subdirectory if they don't already exist. Any files left over from a
+// previous run are picked back up, oldest first, by Dequeue.
+func Open(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, doneDir), 0755); err != nil {
+		return nil, err
+	}
+
+	seq, err := highestSeq(dir)
+	if err != nil {
+		return nil, err
+	}
+	// Acked files keep their names under "done", so new names must not
+	// reuse theirs either.
+	doneSeq, err := highestSeq(filepath.Join(dir, doneDir))
+	if err != nil {
+		return nil, err
+	}
+	if doneSeq > seq {
+		seq = doneSeq
+	}
+
+	return &FileStore{dir: dir, seq: seq, leased: make(map[string]bool)}, nil
+}
+
+func highestSeq(dir string) (uint64, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var max uint64
+	for _, e := range entries {
+		var n uint64
+		if _, err := fmt.Sscanf(e.Name(), "%016d.json", &n); err != nil {
+			continue
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return max, nil
+}
+
+// Enqueue implements store.Store by writing event to a new file under dir,
+// via a temp file plus rename so a crash mid-write never leaves a
+// half-written delivery behind.
+func (f *FileStore) Enqueue(event store.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.seq++
+	name := fmt.Sprintf("%016d.json", f.seq)
+	f.mu.Unlock()
+
+	tmp := filepath.Join(f.dir, "."+name+".tmp")
+	if err := ioutil.WriteFile(tmp, body, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(f.dir, name))
+}
+
+// Dequeue implements store.Store by returning the oldest spooled delivery
+// not already leased to another caller. ack renames the file into dir's
+// "done" subdirectory.
+func (f *FileStore) Dequeue() (store.Event, func() error, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entries, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return store.Event{}, nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" || f.leased[e.Name()] {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	if len(names) == 0 {
+		return store.Event{}, nil, store.ErrEmpty
+	}
+	sort.Strings(names)
+	name := names[0]
+
+	body, err := ioutil.ReadFile(filepath.Join(f.dir, name))
+	if err != nil {
+		return store.Event{}, nil, err
+	}
+	var event store.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return store.Event{}, nil, err
+	}
+
+	f.leased[name] = true
+	ack := func() error {
+		f.mu.Lock()
+		delete(f.leased, name)
+		f.mu.Unlock()
+		return os.Rename(filepath.Join(f.dir, name), filepath.Join(f.dir, doneDir, name))
+	}
+	return event, ack, nil
+}
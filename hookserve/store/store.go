@@ -0,0 +1,43 @@
+// Package store defines a durable, at-least-once delivery queue for
+// hookserve.Server. Unlike Server.Events -- a bounded in-memory channel
+// that drops ordering guarantees under load and loses everything on
+// restart -- a Store persists each delivery before the webhook is
+// acknowledged to the sender, so a crash between accepting a delivery and
+// a consumer finishing work on it just means the delivery is handed out
+// again once the process comes back up.
+package store
+
+import (
+	"errors"
+
+	"github.com/phayes/hookserve/hookserve/providers"
+)
+
+// ErrEmpty is returned by Dequeue when the queue currently has nothing
+// unacked to hand out.
+var ErrEmpty = errors.New("store: queue is empty")
+
+// Event is the envelope a Store persists; it's the same type delivered on
+// Server.Events.
+type Event = providers.Event
+
+// Store is a durable, at-least-once delivery queue. Implementations must
+// be safe for concurrent use.
+type Store interface {
+	// Enqueue durably records event, returning only once the delivery is
+	// safe to assume it will survive a crash.
+	Enqueue(event Event) error
+
+	// Dequeue returns the oldest unacked event along with an ack callback
+	// the caller must invoke once the event has been fully processed.
+	// Until ack is called, the event stays leased to the caller: Dequeue
+	// skips it for as long as this process runs, and it is handed out
+	// again only after a restart. Dequeue returns ErrEmpty rather than
+	// blocking when there is nothing to hand out.
+	//
+	// Implementations persist Event via its MarshalJSON/UnmarshalJSON
+	// wire format, so Payload comes back as the typed struct a Provider
+	// originally produced for known GitHub event types, and as a generic
+	// map[string]interface{} otherwise.
+	Dequeue() (Event, func() error, error)
+}
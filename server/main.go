@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"github.com/codegangsta/cli"
 	"github.com/phayes/hookserve/hookserve"
+	"github.com/phayes/hookserve/hookserve/providers/github/events"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -35,8 +37,11 @@ func main() {
 
 		for {
 			select {
-			case commit := <-server.Events:
-				fmt.Println(commit.Owner + " " + commit.Repo + " " + commit.Branch + " " + commit.Commit)
+			case event := <-server.Events:
+				if push, ok := event.Payload.(*events.PushEvent); ok {
+					branch := strings.TrimPrefix(push.Ref, "refs/heads/")
+					fmt.Println(push.Repository.Owner.Login + " " + push.Repository.Name + " " + branch + " " + push.After)
+				}
 			default:
 				time.Sleep(100)
 			}
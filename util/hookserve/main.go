@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"github.com/codegangsta/cli"
 	"github.com/phayes/hookserve/hookserve"
+	"github.com/phayes/hookserve/hookserve/providers/github/events"
 	"os"
 	"os/exec"
+	"strings"
 )
 
 func main() {
@@ -44,16 +46,25 @@ func main() {
 		server.IgnoreTags = !c.Bool("tags")
 		server.GoListenAndServe()
 
-		for commit := range server.Events {
+		for event := range server.Events {
+			push, ok := event.Payload.(*events.PushEvent)
+			if !ok {
+				continue
+			}
+			owner := push.Repository.Owner.Login
+			repo := push.Repository.Name
+			branch := strings.TrimPrefix(push.Ref, "refs/heads/")
+			commit := push.After
+
 			if args := c.Args(); len(args) != 0 {
 				root := args[0]
-				rest := append(args[1:], commit.Owner, commit.Repo, commit.Branch, commit.Commit)
+				rest := append(args[1:], owner, repo, branch, commit)
 				cmd := exec.Command(root, rest...)
 				cmd.Stdout = os.Stdout
 				cmd.Stderr = os.Stderr
 				cmd.Run()
 			} else {
-				fmt.Println(commit.Owner + " " + commit.Repo + " " + commit.Branch + " " + commit.Commit)
+				fmt.Println(owner + " " + repo + " " + branch + " " + commit)
 			}
 		}
 	}